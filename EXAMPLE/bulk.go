@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yagizhanbilaldurak/dnslookup"
+)
+
+// runBulk drives -bulk: it streams domains from path through a
+// *dnslookup.Resolver and prints one result per line as it arrives, rather
+// than waiting for the whole file to finish like the single-domain path
+// does.
+func runBulk(path, searchTypes, format string, resolver *dnslookup.Resolver, workers int, qps float64, timeout time.Duration) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	types := strings.Split(searchTypes, ",")
+	for i, t := range types {
+		types[i] = strings.TrimSpace(t)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if resolver == nil {
+		resolver = &dnslookup.Resolver{}
+	}
+
+	results := resolver.Bulk(ctx, f, types, dnslookup.BulkOptions{
+		Workers:          workers,
+		QPS:              qps,
+		PerDomainTimeout: timeout,
+	})
+
+	for result := range results {
+		if err := printBulkResult(format, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printBulkResult(format string, result dnslookup.Result) error {
+	switch format {
+	case "", "text", "table", "dig":
+		if result.Err != nil {
+			fmt.Printf("%s\t%s\tERROR\t%v\n", result.Domain, result.Type, result.Err)
+			return nil
+		}
+		fmt.Printf("%s\t%s\t%v\t%s\n", result.Domain, result.Type, result.Records, result.Elapsed)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		return enc.Encode(bulkJSON{
+			Domain:  result.Domain,
+			Type:    result.Type,
+			Records: result.Records,
+			Error:   errString(result.Err),
+			Elapsed: result.Elapsed.String(),
+		})
+	default:
+		return fmt.Errorf("unknown output format %q for -bulk: want text, json, table, or dig", format)
+	}
+}
+
+type bulkJSON struct {
+	Domain  string      `json:"domain"`
+	Type    string      `json:"type"`
+	Records interface{} `json:"records,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Elapsed string      `json:"elapsed"`
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/yagizhanbilaldurak/dnslookup"
 )
@@ -10,57 +14,203 @@ import (
 func main() {
 	// Define and parse command-line flags
 	domainPtr := flag.String("domain", "", "domain")
-	searchTypePtr := flag.String("s", "", "searchType")
+	searchTypePtr := flag.String("s", "", "searchType: a, aaaa, all, cname, mx, ns, ptr, txt")
+	outputPtr := flag.String("o", "text", "output format: text, json, yaml, table, dig")
+	serverPtr := flag.String("server", "", "upstream nameserver to query, e.g. 1.1.1.1:53")
+	timeoutPtr := flag.Duration("timeout", 5*time.Second, "timeout for the whole lookup, retries and -trace included (with -bulk: for each domain and type)")
+	retriesPtr := flag.Int("retries", 0, "number of retries on failure")
+	tcpPtr := flag.Bool("tcp", false, "use TCP instead of UDP to reach the upstream nameserver")
+	ipv4OnlyPtr := flag.Bool("4", false, "only resolve A/IPv4 addresses")
+	ipv6OnlyPtr := flag.Bool("6", false, "only resolve AAAA/IPv6 addresses")
+	tracePtr := flag.Bool("trace", false, "print the domain's NS records before the answer")
+	bulkPtr := flag.String("bulk", "", "path to a file of newline-separated domains to look up concurrently")
+	workersPtr := flag.Int("workers", 50, "number of concurrent workers for -bulk")
+	qpsPtr := flag.Float64("qps", 0, "rate limit, in lookups/sec, for -bulk (0 = unlimited)")
 	flag.Parse()
 
 	// Retrieve values from command-line flags
 	domain := *domainPtr
 	searchType := *searchTypePtr
 
-	// Create a DNS record instance
-	recorder := dnslookup.NewDnsRecord(domain)
+	if *bulkPtr != "" {
+		resolver := buildResolver(*serverPtr, *tcpPtr)
+		if err := runBulk(*bulkPtr, *searchTypePtr, *outputPtr, resolver, *workersPtr, *qpsPtr, *timeoutPtr); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Check if the searchType is valid
-	if !isValidSearchType(searchType) {
+	if !isValidSearchType(searchType) || domain == "" {
 		fmt.Println("error: -domain and -s parameters required. usage: dnssearch -domain example.net -s all")
-		return
-	} else {
-		// Based on the searchType, perform DNS record lookup and print the results
-		switch searchType {
-		case "a":
-			fmt.Println(recorder.GetARecords())
-		case "all":
-			fmt.Println(recorder.GetAllRecords())
-		case "cname":
-			fmt.Println(recorder.GetCnameRecords())
-		case "mx":
-			fmt.Println(recorder.GetMxRecords())
-		case "ns":
-			fmt.Println(recorder.GetNsRecords())
-		case "ptr":
-			fmt.Println(recorder.GetPtrRecords())
-		case "txt":
-			fmt.Println(recorder.GetTxtRecords())
+		os.Exit(1)
+	}
+
+	resolver := buildResolver(*serverPtr, *tcpPtr)
+	recorder := dnslookup.NewDnsRecordWithResolver(domain, resolver)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeoutPtr)
+	defer cancel()
+
+	if *tracePtr {
+		printTrace(ctx, domain, resolver)
+	}
+
+	result, err := lookupWithRetries(ctx, recorder, domain, resolver, searchType, *retriesPtr, *ipv4OnlyPtr, *ipv6OnlyPtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := printResult(os.Stdout, *outputPtr, domain, resolver, result); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// buildResolver assembles a *dnslookup.Resolver from the CLI flags that
+// affect how lookups are performed. It returns nil when none of them were
+// set, so recorder falls back to the system default resolver.
+//
+// Whenever a Resolver is built at all, it gets a Cache: that's what lets
+// cachedLookup's wire-query fast path run and report each record's real
+// TTL (see Resolver.TTLFor), instead of the CLI always displaying
+// dnslookup.DefaultTTL.
+func buildResolver(server string, tcp bool) *dnslookup.Resolver {
+	if server == "" && !tcp {
+		return nil
+	}
+
+	r := &dnslookup.Resolver{Cache: dnslookup.NewLRUCache(256)}
+	if server != "" {
+		r.Servers = []string{server}
+	}
+	if tcp {
+		r.Transport = dnslookup.TransportTCP
+	}
+	return r
+}
+
+// lookupWithRetries runs the lookup for searchType, retrying up to retries
+// times (in addition to the first attempt) if it fails.
+func lookupWithRetries(ctx context.Context, recorder *dnslookup.DnsRecord, domain string, resolver *dnslookup.Resolver, searchType string, retries int, ipv4Only, ipv6Only bool) (map[string]recordValue, error) {
+	var result map[string]recordValue
+	var err error
+
+	for attempt := 0; attempt <= retries; attempt++ {
+		result, err = lookup(ctx, recorder, domain, resolver, searchType, ipv4Only, ipv6Only)
+		if err == nil {
+			return result, nil
 		}
 	}
 
+	return nil, err
 }
 
-func isValidSearchType(searchType string) bool {
+// lookup performs a single lookup for searchType and returns it as a
+// {"<TYPE>": recordValue} map so printResult can render it uniformly.
+// ipv4Only leaves AAAA out of "all" and ipv6Only leaves A out of it; the
+// other rrtypes are looked up either way, and other searchTypes ignore both.
+func lookup(ctx context.Context, recorder *dnslookup.DnsRecord, domain string, resolver *dnslookup.Resolver, searchType string, ipv4Only, ipv6Only bool) (map[string]recordValue, error) {
+	records := make(map[string]recordValue)
 
-	searchOptions := []string{"a", "all", "cname", "mx", "ns", "ptr", "txt"}
+	// set records rrtype's value alongside the real TTL resolver's Cache
+	// ended up holding for (domain, rrtype), if any.
+	set := func(rrtype string, value interface{}) {
+		ttl, _ := resolver.TTLFor(domain, rrtype)
+		records[rrtype] = recordValue{Value: value, TTL: ttl}
+	}
 
-	found := true
+	switch searchType {
+	case "a":
+		v, err := recorder.GetARecordsCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set("A", v)
+	case "aaaa":
+		v, err := recorder.GetAAAARecords(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set("AAAA", v)
+	case "cname":
+		v, err := recorder.GetCnameRecordsCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set("CNAME", v)
+	case "mx":
+		v, err := recorder.GetMxRecordsCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set("MX", v)
+	case "ns":
+		v, err := recorder.GetNsRecordsCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set("NS", v)
+	case "ptr":
+		v, err := recorder.GetPtrRecordsCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set("PTR", v)
+	case "txt":
+		v, err := recorder.GetTxtRecordsCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		set("TXT", v)
+	case "all":
+		if !ipv6Only {
+			a, _ := recorder.GetARecordsCtx(ctx)
+			set("A", a)
+		}
+		if !ipv4Only {
+			aaaa, _ := recorder.GetAAAARecords(ctx)
+			set("AAAA", aaaa)
+		}
+		cname, _ := recorder.GetCnameRecordsCtx(ctx)
+		mx, _ := recorder.GetMxRecordsCtx(ctx)
+		ns, _ := recorder.GetNsRecordsCtx(ctx)
+		ptr, _ := recorder.GetPtrRecordsCtx(ctx)
+		txt, _ := recorder.GetTxtRecordsCtx(ctx)
+		set("CNAME", cname)
+		set("MX", mx)
+		set("NS", ns)
+		set("PTR", ptr)
+		set("TXT", txt)
+	}
+
+	return records, nil
+}
+
+// printTrace prints domain's own NS records, one per line. It does not walk
+// the delegation down from the root the way `dig +trace` does.
+func printTrace(ctx context.Context, domain string, resolver *dnslookup.Resolver) {
+	recorder := dnslookup.NewDnsRecordWithResolver(domain, resolver)
+	nsRecords, err := recorder.GetNsRecordsCtx(ctx)
+	if err != nil {
+		fmt.Printf(";; trace: could not resolve NS for %s: %v\n", domain, err)
+		return
+	}
+
+	for _, ns := range nsRecords {
+		fmt.Printf(";; %s -> NS %s\n", domain, strings.TrimSuffix(ns.Host, "."))
+	}
+}
+
+func isValidSearchType(searchType string) bool {
+	searchOptions := []string{"a", "aaaa", "all", "cname", "mx", "ns", "ptr", "txt"}
 
 	for _, v := range searchOptions {
-		if searchType != v {
-			found = false
-		} else {
+		if searchType == v {
 			return true
 		}
 	}
 
-	return found
-
+	return false
 }
-
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yagizhanbilaldurak/dnslookup"
+)
+
+// jsonResult is the stable schema emitted by -o json and -o yaml:
+//
+//	{"domain":..., "records":{"A":[{"ip":"1.2.3.4","ttl":300}], "MX":[{"host":"mail.example.com.","pref":10,"ttl":300}], ...}, "queried_at":..., "resolver":...}
+type jsonResult struct {
+	Domain    string                 `json:"domain"`
+	Records   map[string]interface{} `json:"records"`
+	QueriedAt string                 `json:"queried_at"`
+	Resolver  string                 `json:"resolver"`
+}
+
+// recordValue pairs an rrtype's looked-up value with the TTL remaining on
+// its cache entry, when known (see Resolver.TTLFor). A zero TTL means no
+// fresh cache entry was found - e.g. no Resolver.Cache configured - and
+// callers should report dnslookup.DefaultTTL instead. When the lookup fell
+// back to the net.LookupX-based path, the entry was cached under
+// dnslookup.DefaultTTL, so TTL is what remains of that.
+type recordValue struct {
+	Value interface{}
+	TTL   time.Duration
+}
+
+// aRecordJSON, mxRecordJSON, nsRecordJSON, and strRecordJSON are the
+// lowercase-field, per-record shapes jsonResult.Records wraps each value
+// in, so -o json/-o yaml report a real ttl alongside each record instead of
+// a bare slice of Go values.
+type aRecordJSON struct {
+	IP  string `json:"ip"`
+	TTL int    `json:"ttl"`
+}
+
+type mxRecordJSON struct {
+	Host string `json:"host"`
+	Pref int    `json:"pref"`
+	TTL  int    `json:"ttl"`
+}
+
+type nsRecordJSON struct {
+	Host string `json:"host"`
+	TTL  int    `json:"ttl"`
+}
+
+type strRecordJSON struct {
+	Value string `json:"value"`
+	TTL   int    `json:"ttl"`
+}
+
+// printResult renders records in the requested format to w.
+func printResult(w io.Writer, format, domain string, resolver *dnslookup.Resolver, records map[string]recordValue) error {
+	switch format {
+	case "", "text":
+		return printText(w, records)
+	case "json":
+		return printJSON(w, domain, resolver, records)
+	case "yaml":
+		return printYAML(w, domain, resolver, records)
+	case "table":
+		return printTable(w, records)
+	case "dig":
+		return printDig(w, domain, records)
+	default:
+		return fmt.Errorf("unknown output format %q: want text, json, yaml, table, or dig", format)
+	}
+}
+
+// printText preserves the original behavior of just printing each result
+// with Go's default formatting.
+func printText(w io.Writer, records map[string]recordValue) error {
+	for _, rrtype := range sortedKeys(records) {
+		fmt.Fprintln(w, records[rrtype].Value)
+	}
+	return nil
+}
+
+func printJSON(w io.Writer, domain string, resolver *dnslookup.Resolver, records map[string]recordValue) error {
+	result := toJSONResult(domain, resolver, records)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// printYAML renders the same schema as printJSON, but as YAML. There is no
+// vendored YAML encoder in this module, so this walks the generic
+// map/slice/scalar shape produced by toJSONResult by hand; it is not a
+// general-purpose YAML marshaler.
+func printYAML(w io.Writer, domain string, resolver *dnslookup.Resolver, records map[string]recordValue) error {
+	result := toJSONResult(domain, resolver, records)
+
+	// Round-trip through JSON to normalize every value (net.IP, net.MX,
+	// ...) down to the plain maps/slices/scalars writeYAML knows how to
+	// walk.
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return err
+	}
+
+	writeYAML(w, generic, 0)
+	return nil
+}
+
+func toJSONResult(domain string, resolver *dnslookup.Resolver, records map[string]recordValue) jsonResult {
+	resolverDesc := "system"
+	if resolver != nil && len(resolver.Servers) > 0 {
+		resolverDesc = resolver.Servers[0]
+	}
+
+	return jsonResult{
+		Domain:    domain,
+		Records:   toJSONRecords(records),
+		QueriedAt: time.Now().UTC().Format(time.RFC3339),
+		Resolver:  resolverDesc,
+	}
+}
+
+// toJSONRecords wraps each rrtype's value in the matching lowercase-field
+// struct (aRecordJSON, mxRecordJSON, ...) with its real ttl, so the emitted
+// JSON/YAML schema never exposes Go's capitalized field names (net.MX.Host,
+// net.MX.Pref, ...) directly.
+func toJSONRecords(records map[string]recordValue) map[string]interface{} {
+	out := make(map[string]interface{}, len(records))
+	for rrtype, rv := range records {
+		ttl := ttlSeconds(rv.TTL)
+
+		switch v := rv.Value.(type) {
+		case []net.IP:
+			rows := make([]aRecordJSON, 0, len(v))
+			for _, ip := range v {
+				rows = append(rows, aRecordJSON{IP: ip.String(), TTL: ttl})
+			}
+			out[rrtype] = rows
+		case []net.MX:
+			rows := make([]mxRecordJSON, 0, len(v))
+			for _, mx := range v {
+				rows = append(rows, mxRecordJSON{Host: mx.Host, Pref: int(mx.Pref), TTL: ttl})
+			}
+			out[rrtype] = rows
+		case []net.NS:
+			rows := make([]nsRecordJSON, 0, len(v))
+			for _, ns := range v {
+				rows = append(rows, nsRecordJSON{Host: ns.Host, TTL: ttl})
+			}
+			out[rrtype] = rows
+		case []string:
+			rows := make([]strRecordJSON, 0, len(v))
+			for _, s := range v {
+				rows = append(rows, strRecordJSON{Value: s, TTL: ttl})
+			}
+			out[rrtype] = rows
+		case string:
+			if v == "" {
+				out[rrtype] = []strRecordJSON{}
+			} else {
+				out[rrtype] = []strRecordJSON{{Value: v, TTL: ttl}}
+			}
+		default:
+			out[rrtype] = v
+		}
+	}
+	return out
+}
+
+// ttlSeconds converts ttl to whole seconds for JSON/dig output, falling
+// back to dnslookup.DefaultTTL when the real TTL wasn't available.
+func ttlSeconds(ttl time.Duration) int {
+	if ttl <= 0 {
+		return int(dnslookup.DefaultTTL.Seconds())
+	}
+	return int(ttl.Seconds())
+}
+
+func writeYAML(w io.Writer, v interface{}, indent int) {
+	pad := func(n int) string {
+		s := ""
+		for i := 0; i < n; i++ {
+			s += "  "
+		}
+		return s
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedGenericKeys(val) {
+			child := val[key]
+			switch child.(type) {
+			case map[string]interface{}, []interface{}:
+				fmt.Fprintf(w, "%s%s:\n", pad(indent), key)
+				writeYAML(w, child, indent+1)
+			default:
+				fmt.Fprintf(w, "%s%s: %v\n", pad(indent), key, child)
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			fmt.Fprintf(w, "%s- ", pad(indent))
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				fmt.Fprintln(w)
+				writeYAML(w, item, indent+1)
+			default:
+				fmt.Fprintf(w, "%v\n", item)
+			}
+		}
+	default:
+		fmt.Fprintf(w, "%s%v\n", pad(indent), val)
+	}
+}
+
+func printTable(w io.Writer, records map[string]recordValue) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tVALUE")
+	for _, rrtype := range sortedKeys(records) {
+		for _, line := range renderDigRows("", rrtype, records[rrtype]) {
+			fmt.Fprintf(tw, "%s\t%s\n", rrtype, line.rdata)
+		}
+	}
+	return tw.Flush()
+}
+
+// printDig mimics `dig +noall +answer`: one line per record with name,
+// TTL, class, type, and rdata.
+func printDig(w io.Writer, domain string, records map[string]recordValue) error {
+	for _, rrtype := range sortedKeys(records) {
+		for _, row := range renderDigRows(domain, rrtype, records[rrtype]) {
+			fmt.Fprintf(w, "%s\t%d\tIN\t%s\t%s\n", row.name, row.ttl, rrtype, row.rdata)
+		}
+	}
+	return nil
+}
+
+type digRow struct {
+	name  string
+	ttl   int
+	rdata string
+}
+
+// renderDigRows flattens one GetX result into zero or more dig-style rows,
+// using rv's real TTL when one was available (see Resolver.TTLFor) and
+// falling back to dnslookup.DefaultTTL otherwise.
+func renderDigRows(domain, rrtype string, rv recordValue) []digRow {
+	ttl := ttlSeconds(rv.TTL)
+
+	switch v := rv.Value.(type) {
+	case []net.IP:
+		rows := make([]digRow, 0, len(v))
+		for _, ip := range v {
+			rows = append(rows, digRow{name: domain, ttl: ttl, rdata: ip.String()})
+		}
+		return rows
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []digRow{{name: domain, ttl: ttl, rdata: v}}
+	case []net.MX:
+		rows := make([]digRow, 0, len(v))
+		for _, mx := range v {
+			rows = append(rows, digRow{name: domain, ttl: ttl, rdata: fmt.Sprintf("%d %s", mx.Pref, mx.Host)})
+		}
+		return rows
+	case []net.NS:
+		rows := make([]digRow, 0, len(v))
+		for _, ns := range v {
+			rows = append(rows, digRow{name: domain, ttl: ttl, rdata: ns.Host})
+		}
+		return rows
+	case []string:
+		rows := make([]digRow, 0, len(v))
+		for _, s := range v {
+			rows = append(rows, digRow{name: domain, ttl: ttl, rdata: s})
+		}
+		return rows
+	default:
+		return []digRow{{name: domain, ttl: ttl, rdata: fmt.Sprintf("%v", v)}}
+	}
+}
+
+func sortedKeys(m map[string]recordValue) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedGenericKeys is sortedKeys' counterpart for the generic
+// map[string]interface{} shape writeYAML walks, produced by round-tripping
+// a jsonResult through encoding/json.
+func sortedGenericKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,182 @@
+package dnslookup
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result is a single lookup performed as part of a bulk run: the domain
+// and rrtype that were queried, what came back (or the error), and how
+// long the lookup took.
+type Result struct {
+	Domain  string
+	Type    string
+	Records interface{}
+	Err     error
+	Elapsed time.Duration
+}
+
+// BulkOptions configures LookupBulk and Bulk.
+type BulkOptions struct {
+	// Workers is how many domains are looked up concurrently. Defaults
+	// to 50 if zero or negative.
+	Workers int
+
+	// QPS caps how many lookups per second are started, across all
+	// workers combined, using a simple token-bucket ticker. Zero or
+	// negative means unlimited.
+	QPS float64
+
+	// PerDomainTimeout bounds each individual lookup so one slow domain
+	// can't stall the whole run. Zero means the lookup only respects
+	// the context passed to LookupBulk/Bulk.
+	PerDomainTimeout time.Duration
+}
+
+func (o BulkOptions) workers() int {
+	if o.Workers <= 0 {
+		return 50
+	}
+	return o.Workers
+}
+
+// LookupBulk looks up types for every domain received on domains,
+// concurrently across opts.Workers goroutines, optionally rate
+// limited to opts.QPS lookups/sec. The returned channel is closed once
+// domains is drained and every in-flight lookup has reported its Result;
+// it delivers len(types) Results per domain.
+//
+// The number of live worker goroutines is bounded by opts.Workers at every
+// point during the run, not just at the end of it: a worker releases its
+// slot only after its Result has been received (or ctx is done), so a slow
+// consumer of the returned channel holds back the producer instead of
+// piling up blocked goroutines behind it.
+func (r *Resolver) LookupBulk(ctx context.Context, domains <-chan string, types []string, opts BulkOptions) <-chan Result {
+	out := make(chan Result)
+
+	var limiter *time.Ticker
+	if opts.QPS > 0 {
+		limiter = time.NewTicker(time.Duration(float64(time.Second) / opts.QPS))
+	}
+
+	sem := make(chan struct{}, opts.workers())
+	var wg sync.WaitGroup
+
+	go func() {
+		defer func() {
+			wg.Wait()
+			close(out)
+			if limiter != nil {
+				limiter.Stop()
+			}
+		}()
+
+	produce:
+		for domain := range domains {
+			for _, rrtype := range types {
+				if limiter != nil {
+					select {
+					case <-limiter.C:
+					case <-ctx.Done():
+						break produce
+					}
+				}
+
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					break produce
+				}
+
+				domain, rrtype := domain, rrtype
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-sem }()
+
+					lookupCtx := ctx
+					if opts.PerDomainTimeout > 0 {
+						var cancel context.CancelFunc
+						lookupCtx, cancel = context.WithTimeout(ctx, opts.PerDomainTimeout)
+						defer cancel()
+					}
+
+					result := r.lookupOne(lookupCtx, domain, rrtype)
+					select {
+					case out <- result:
+					case <-ctx.Done():
+					}
+				}()
+			}
+		}
+	}()
+
+	return out
+}
+
+// lookupOne runs a single (domain, rrtype) lookup and times it, used by
+// LookupBulk.
+func (r *Resolver) lookupOne(ctx context.Context, domain, rrtype string) Result {
+	start := time.Now()
+	d := NewDnsRecordWithResolver(domain, r)
+
+	var records interface{}
+	var err error
+
+	switch strings.ToLower(rrtype) {
+	case "a":
+		records, err = d.GetARecordsCtx(ctx)
+	case "aaaa":
+		records, err = d.GetAAAARecords(ctx)
+	case "cname":
+		records, err = d.GetCnameRecordsCtx(ctx)
+	case "mx":
+		records, err = d.GetMxRecordsCtx(ctx)
+	case "ns":
+		records, err = d.GetNsRecordsCtx(ctx)
+	case "ptr":
+		records, err = d.GetPtrRecordsCtx(ctx)
+	case "txt":
+		records, err = d.GetTxtRecordsCtx(ctx)
+	default:
+		err = ErrUnsupported
+	}
+
+	return Result{
+		Domain:  domain,
+		Type:    strings.ToUpper(rrtype),
+		Records: records,
+		Err:     err,
+		Elapsed: time.Since(start),
+	}
+}
+
+// Bulk reads one domain per line from src (blank lines are skipped) and
+// looks up types for each of them using r, returning a channel of Results
+// as described by LookupBulk. Bulk closes the channel once src is
+// exhausted and every lookup has completed.
+func (r *Resolver) Bulk(ctx context.Context, src io.Reader, types []string, opts BulkOptions) <-chan Result {
+	domains := make(chan string)
+
+	go func() {
+		defer close(domains)
+		scanner := bufio.NewScanner(src)
+		for scanner.Scan() {
+			domain := strings.TrimSpace(scanner.Text())
+			if domain == "" {
+				continue
+			}
+			select {
+			case domains <- domain:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return r.LookupBulk(ctx, domains, types, opts)
+}
@@ -0,0 +1,236 @@
+package dnslookup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is the TTL applied to a cache entry when the underlying lookup
+// does not expose a record's real TTL: rrtypes cachedLookup has no wire
+// decoder for (e.g. SRV), and any rrtype when the wire query itself fails
+// and the net.LookupX-based fallback is used instead.
+const DefaultTTL = 5 * time.Minute
+
+// DefaultNegativeTTL is how long an NXDOMAIN/NODATA result is cached before
+// it is retried.
+const DefaultNegativeTTL = 30 * time.Second
+
+// cacheKey identifies a single (domain, rrtype) lookup in the Cache.
+type cacheKey struct {
+	domain string
+	rrtype string
+}
+
+// cacheEntry is a cached lookup result together with its expiry and, for
+// negative results, the error that was cached.
+type cacheEntry struct {
+	value     interface{}
+	err       error
+	expiresAt time.Time
+}
+
+func (e *cacheEntry) expired() bool {
+	return time.Now().After(e.expiresAt)
+}
+
+// Cache is the interface a DNS record cache must satisfy. The zero value of
+// Resolver uses no cache; set Resolver.Cache to an *LRUCache (or a custom
+// implementation) to opt in.
+type Cache interface {
+	// Get returns the cached value for key, and whether it was found and
+	// still fresh.
+	Get(key string) (value interface{}, err error, ok bool)
+
+	// Set stores value (or err, for a negative cache entry) under key
+	// until ttl elapses.
+	Set(key string, value interface{}, err error, ttl time.Duration)
+
+	// RefreshExpired re-runs refresh for every entry whose TTL is within
+	// threshold of expiring, replacing the cached value on success.
+	RefreshExpired(ctx context.Context, threshold time.Duration, refresh func(ctx context.Context, key string) (interface{}, error, time.Duration))
+
+	// TTL returns the remaining time-to-live for key's cached entry, and
+	// whether one exists and is still fresh. It lets a caller that
+	// already ran the lookup (populating the cache as a side effect)
+	// recover the real TTL it was cached under, e.g. to display it.
+	TTL(key string) (time.Duration, bool)
+}
+
+// LRUCache is a bounded, in-memory Cache with per-entry expiry and negative
+// caching for failed lookups. Entries are evicted lazily on access, plus
+// least-recently-used eviction once MaxEntries is exceeded.
+//
+// LRUCache is safe for concurrent use.
+type LRUCache struct {
+	// MaxEntries bounds how many entries the cache holds. Zero means
+	// unbounded.
+	MaxEntries int
+
+	// NegativeTTL is how long a failed lookup is cached for. Zero means
+	// DefaultNegativeTTL.
+	NegativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	order   []string // most-recently-used at the end
+}
+
+// NewLRUCache returns an LRUCache bounded to maxEntries entries (0 for
+// unbounded).
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		MaxEntries: maxEntries,
+		entries:    make(map[string]*cacheEntry),
+	}
+}
+
+// Get implements Cache.
+func (c *LRUCache) Get(key string) (interface{}, error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	if entry.expired() {
+		delete(c.entries, key)
+		for i, k := range c.order {
+			if k == key {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+		return nil, nil, false
+	}
+
+	c.touch(key)
+	return entry.value, entry.err, true
+}
+
+// Set implements Cache.
+func (c *LRUCache) Set(key string, value interface{}, err error, ttl time.Duration) {
+	if err != nil && ttl <= 0 {
+		ttl = c.negativeTTL()
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &cacheEntry{
+		value:     value,
+		err:       err,
+		expiresAt: time.Now().Add(ttl),
+	}
+	c.touch(key)
+	c.evictLocked()
+}
+
+// TTL implements Cache.
+func (c *LRUCache) TTL(key string) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || entry.expired() {
+		return 0, false
+	}
+	return time.Until(entry.expiresAt), true
+}
+
+// RefreshExpired implements Cache.
+func (c *LRUCache) RefreshExpired(ctx context.Context, threshold time.Duration, refresh func(ctx context.Context, key string) (interface{}, error, time.Duration)) {
+	c.mu.Lock()
+	stale := make([]string, 0)
+	now := time.Now()
+	for key, entry := range c.entries {
+		if entry.expiresAt.Sub(now) <= threshold {
+			stale = append(stale, key)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, key := range stale {
+		value, err, ttl := refresh(ctx, key)
+		if err != nil {
+			continue
+		}
+		c.Set(key, value, err, ttl)
+	}
+}
+
+func (c *LRUCache) negativeTTL() time.Duration {
+	if c.NegativeTTL > 0 {
+		return c.NegativeTTL
+	}
+	return DefaultNegativeTTL
+}
+
+// touch marks key as the most recently used entry. Callers must hold c.mu.
+func (c *LRUCache) touch(key string) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, key)
+}
+
+// evictLocked removes the least-recently-used entries until the cache is
+// within MaxEntries. Callers must hold c.mu.
+func (c *LRUCache) evictLocked() {
+	if c.MaxEntries <= 0 {
+		return
+	}
+	for len(c.order) > c.MaxEntries {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		delete(c.entries, oldest)
+	}
+}
+
+// singleFlightGroup coalesces concurrent calls for the same key into a
+// single in-flight call, so N callers asking for the same (domain, rrtype)
+// trigger one network lookup.
+type singleFlightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*singleFlightCall
+}
+
+type singleFlightCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// do executes fn for key, or waits for an identical in-flight call.
+func (g *singleFlightGroup) do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleFlightCall)
+	}
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.value, call.err
+	}
+
+	call := &singleFlightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.value, call.err = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.value, call.err
+}
@@ -0,0 +1,417 @@
+package dnslookup
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Wire RR type values, per RFC 1035 and its successors. Only the types this
+// package knows how to decode are listed.
+const (
+	typeA      uint16 = 1
+	typeNS     uint16 = 2
+	typeCNAME  uint16 = 5
+	typeSOA    uint16 = 6
+	typePTR    uint16 = 12
+	typeMX     uint16 = 15
+	typeTXT    uint16 = 16
+	typeAAAA   uint16 = 28
+	typeSRV    uint16 = 33
+	typeDS     uint16 = 43
+	typeRRSIG  uint16 = 46
+	typeDNSKEY uint16 = 48
+	typeCAA    uint16 = 257
+)
+
+const classIN uint16 = 1
+
+// rcode values from the DNS header.
+const (
+	rcodeNoError  = 0
+	rcodeNXDomain = 3
+)
+
+// ErrNXDomain is returned when a wire query gets an authoritative "no such
+// domain" answer.
+var ErrNXDomain = errors.New("dnslookup: no such domain")
+
+// wireMsgID is fixed rather than random because this package has no need to
+// multiplex concurrent queries over one connection; each query uses its own
+// net.Conn (see Resolver.dialWire).
+const wireMsgID uint16 = 0x1234
+
+// wireRR is one decoded answer resource record, with its real on-the-wire
+// TTL (what TTL-aware caching keys off of) and its type-specific payload in
+// RData.
+type wireRR struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+	RData interface{}
+	Raw   []byte
+}
+
+// buildQuery encodes a single-question DNS query for name/qtype with the
+// recursion-desired bit set, optionally requesting DNSSEC records (the DO
+// bit, RFC 3225) via an OPT pseudo-RR.
+func buildQuery(name string, qtype uint16, dnssec bool) []byte {
+	var buf []byte
+
+	buf = appendUint16(buf, wireMsgID)
+	flags := uint16(0x0100) // RD=1
+	buf = appendUint16(buf, flags)
+	buf = appendUint16(buf, 1) // QDCOUNT
+	buf = appendUint16(buf, 0) // ANCOUNT
+	buf = appendUint16(buf, 0) // NSCOUNT
+	if dnssec {
+		buf = appendUint16(buf, 1) // ARCOUNT (OPT record)
+	} else {
+		buf = appendUint16(buf, 0)
+	}
+
+	buf = append(buf, encodeName(name)...)
+	buf = appendUint16(buf, qtype)
+	buf = appendUint16(buf, classIN)
+
+	if dnssec {
+		buf = append(buf, 0) // root name
+		buf = appendUint16(buf, 41) // TYPE OPT
+		buf = appendUint16(buf, 4096) // requestor's UDP payload size
+		buf = append(buf, 0, 0) // extended RCODE + version
+		buf = appendUint16(buf, 0x8000) // flags: DO bit set
+		buf = appendUint16(buf, 0)      // RDLENGTH
+	}
+
+	return buf
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	var tmp [2]byte
+	binary.BigEndian.PutUint16(tmp[:], v)
+	return append(buf, tmp[:]...)
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var tmp [4]byte
+	binary.BigEndian.PutUint32(tmp[:], v)
+	return append(buf, tmp[:]...)
+}
+
+// encodeName encodes name as a sequence of length-prefixed labels
+// terminated by a zero-length label, per RFC 1035 section 3.1.
+func encodeName(name string) []byte {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return []byte{0}
+	}
+
+	var buf []byte
+	for _, label := range strings.Split(name, ".") {
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+	}
+	buf = append(buf, 0)
+	return buf
+}
+
+// decodeName decodes a (possibly compressed) domain name starting at
+// offset within msg, returning the name and the offset immediately past it
+// in the original stream (not following any compression pointer).
+func decodeName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	originalOffset := -1
+	cur := offset
+	hops := 0
+
+	for {
+		hops++
+		if hops > 128 {
+			return "", 0, errors.New("dnslookup: compressed name pointer loop")
+		}
+		if cur >= len(msg) {
+			return "", 0, errors.New("dnslookup: name extends past end of message")
+		}
+
+		length := int(msg[cur])
+		if length == 0 {
+			cur++
+			break
+		}
+
+		if length&0xC0 == 0xC0 {
+			if cur+1 >= len(msg) {
+				return "", 0, errors.New("dnslookup: truncated name pointer")
+			}
+			if originalOffset == -1 {
+				originalOffset = cur + 2
+			}
+			pointer := int(binary.BigEndian.Uint16(msg[cur:cur+2]) & 0x3FFF)
+			cur = pointer
+			continue
+		}
+
+		if cur+1+length > len(msg) {
+			return "", 0, errors.New("dnslookup: label extends past end of message")
+		}
+		labels = append(labels, string(msg[cur+1:cur+1+length]))
+		cur += 1 + length
+	}
+
+	if originalOffset != -1 {
+		cur = originalOffset
+	}
+
+	return strings.Join(labels, "."), cur, nil
+}
+
+// parseResponse decodes a DNS message, skips its question section, and
+// returns its answer-section RRs decoded per their type. It does not check
+// the message ID or that the question matches the query that was sent.
+func parseResponse(msg []byte) ([]wireRR, error) {
+	if len(msg) < 12 {
+		return nil, errors.New("dnslookup: response shorter than a DNS header")
+	}
+
+	flags := binary.BigEndian.Uint16(msg[2:4])
+	rcode := int(flags & 0x000F)
+
+	qdcount := int(binary.BigEndian.Uint16(msg[4:6]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:8]))
+
+	offset := 12
+	for i := 0; i < qdcount; i++ {
+		_, next, err := decodeName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4 // QTYPE + QCLASS
+	}
+
+	if rcode == rcodeNXDomain {
+		return nil, ErrNXDomain
+	}
+	if rcode != rcodeNoError {
+		return nil, fmt.Errorf("dnslookup: server returned rcode %d", rcode)
+	}
+
+	rrs := make([]wireRR, 0, ancount)
+	for i := 0; i < ancount; i++ {
+		name, next, err := decodeName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next
+
+		if offset+10 > len(msg) {
+			return nil, errors.New("dnslookup: truncated RR header")
+		}
+		rrtype := binary.BigEndian.Uint16(msg[offset : offset+2])
+		rrclass := binary.BigEndian.Uint16(msg[offset+2 : offset+4])
+		ttl := binary.BigEndian.Uint32(msg[offset+4 : offset+8])
+		rdlength := int(binary.BigEndian.Uint16(msg[offset+8 : offset+10]))
+		offset += 10
+
+		if offset+rdlength > len(msg) {
+			return nil, errors.New("dnslookup: truncated RDATA")
+		}
+		rdata := msg[offset : offset+rdlength]
+
+		decoded, err := decodeRData(msg, offset, rrtype, rdata)
+		if err != nil {
+			return nil, err
+		}
+
+		rrs = append(rrs, wireRR{
+			Name:  name,
+			Type:  rrtype,
+			Class: rrclass,
+			TTL:   ttl,
+			RData: decoded,
+			Raw:   rdata,
+		})
+
+		offset += rdlength
+	}
+
+	return rrs, nil
+}
+
+// decodeRData interprets rdata according to rrtype. msg/rdataOffset are
+// needed for types whose rdata can itself contain compressed names (NS,
+// CNAME, MX, SOA).
+func decodeRData(msg []byte, rdataOffset int, rrtype uint16, rdata []byte) (interface{}, error) {
+	switch rrtype {
+	case typeA:
+		if len(rdata) != 4 {
+			return nil, errors.New("dnslookup: malformed A record")
+		}
+		return net.IP(append([]byte{}, rdata...)), nil
+
+	case typeAAAA:
+		if len(rdata) != 16 {
+			return nil, errors.New("dnslookup: malformed AAAA record")
+		}
+		return net.IP(append([]byte{}, rdata...)), nil
+
+	case typeNS, typeCNAME, typePTR:
+		name, _, err := decodeName(msg, rdataOffset)
+		return name, err
+
+	case typeMX:
+		if len(rdata) < 3 {
+			return nil, errors.New("dnslookup: malformed MX record")
+		}
+		pref := binary.BigEndian.Uint16(rdata[:2])
+		host, _, err := decodeName(msg, rdataOffset+2)
+		if err != nil {
+			return nil, err
+		}
+		return net.MX{Host: host + ".", Pref: pref}, nil
+
+	case typeTXT:
+		var chunks []string
+		i := 0
+		for i < len(rdata) {
+			n := int(rdata[i])
+			i++
+			if i+n > len(rdata) {
+				return nil, errors.New("dnslookup: malformed TXT record")
+			}
+			chunks = append(chunks, string(rdata[i:i+n]))
+			i += n
+		}
+		return strings.Join(chunks, ""), nil
+
+	case typeSOA:
+		mname, next, err := decodeName(msg, rdataOffset)
+		if err != nil {
+			return nil, err
+		}
+		rname, next, err := decodeName(msg, next)
+		if err != nil {
+			return nil, err
+		}
+		relOffset := next - rdataOffset
+		if relOffset+20 > len(rdata) {
+			return nil, errors.New("dnslookup: malformed SOA record")
+		}
+		fields := rdata[relOffset:]
+		return SOARecord{
+			PrimaryNs:   mname,
+			RespMailbox: rname,
+			Serial:      binary.BigEndian.Uint32(fields[0:4]),
+			Refresh:     binary.BigEndian.Uint32(fields[4:8]),
+			Retry:       binary.BigEndian.Uint32(fields[8:12]),
+			Expire:      binary.BigEndian.Uint32(fields[12:16]),
+			MinTTL:      binary.BigEndian.Uint32(fields[16:20]),
+		}, nil
+
+	case typeSRV:
+		if len(rdata) < 7 {
+			return nil, errors.New("dnslookup: malformed SRV record")
+		}
+		target, _, err := decodeName(msg, rdataOffset+6)
+		if err != nil {
+			return nil, err
+		}
+		return &net.SRV{
+			Priority: binary.BigEndian.Uint16(rdata[0:2]),
+			Weight:   binary.BigEndian.Uint16(rdata[2:4]),
+			Port:     binary.BigEndian.Uint16(rdata[4:6]),
+			Target:   target + ".",
+		}, nil
+
+	case typeCAA:
+		if len(rdata) < 2 {
+			return nil, errors.New("dnslookup: malformed CAA record")
+		}
+		tagLen := int(rdata[1])
+		if 2+tagLen > len(rdata) {
+			return nil, errors.New("dnslookup: malformed CAA record")
+		}
+		return CAARecord{
+			Flag:  rdata[0],
+			Tag:   string(rdata[2 : 2+tagLen]),
+			Value: string(rdata[2+tagLen:]),
+		}, nil
+
+	case typeDNSKEY:
+		if len(rdata) < 4 {
+			return nil, errors.New("dnslookup: malformed DNSKEY record")
+		}
+		return dnskeyRR{
+			Flags:     binary.BigEndian.Uint16(rdata[0:2]),
+			Protocol:  rdata[2],
+			Algorithm: rdata[3],
+			PublicKey: append([]byte{}, rdata[4:]...),
+		}, nil
+
+	case typeDS:
+		if len(rdata) < 4 {
+			return nil, errors.New("dnslookup: malformed DS record")
+		}
+		return dsRR{
+			KeyTag:     binary.BigEndian.Uint16(rdata[0:2]),
+			Algorithm:  rdata[2],
+			DigestType: rdata[3],
+			Digest:     append([]byte{}, rdata[4:]...),
+		}, nil
+
+	case typeRRSIG:
+		if len(rdata) < 18 {
+			return nil, errors.New("dnslookup: malformed RRSIG record")
+		}
+		signerName, next, err := decodeName(msg, rdataOffset+18)
+		if err != nil {
+			return nil, err
+		}
+		sigStart := next - rdataOffset
+		return rrsigRR{
+			TypeCovered: binary.BigEndian.Uint16(rdata[0:2]),
+			Algorithm:   rdata[2],
+			Labels:      rdata[3],
+			OriginalTTL: binary.BigEndian.Uint32(rdata[4:8]),
+			Expiration:  binary.BigEndian.Uint32(rdata[8:12]),
+			Inception:   binary.BigEndian.Uint32(rdata[12:16]),
+			KeyTag:      binary.BigEndian.Uint16(rdata[16:18]),
+			SignerName:  signerName,
+			Signature:   append([]byte{}, rdata[sigStart:]...),
+		}, nil
+
+	default:
+		return append([]byte{}, rdata...), nil
+	}
+}
+
+// dnskeyRR is a decoded DNSKEY record (RFC 4034 section 2).
+type dnskeyRR struct {
+	Flags     uint16
+	Protocol  uint8
+	Algorithm uint8
+	PublicKey []byte
+}
+
+// dsRR is a decoded DS record (RFC 4034 section 5).
+type dsRR struct {
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     []byte
+}
+
+// rrsigRR is a decoded RRSIG record (RFC 4034 section 3).
+type rrsigRR struct {
+	TypeCovered uint16
+	Algorithm   uint8
+	Labels      uint8
+	OriginalTTL uint32
+	Expiration  uint32
+	Inception   uint32
+	KeyTag      uint16
+	SignerName  string
+	Signature   []byte
+}
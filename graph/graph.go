@@ -0,0 +1,353 @@
+// Package graph walks a domain's NS delegation, CNAME and MX chains and
+// assembles them into a dependency graph, so callers can spot cycles and
+// single points of failure (e.g. nameservers that all sit behind the same
+// network) instead of only seeing one flat record list at a time.
+package graph
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yagizhanbilaldurak/dnslookup"
+)
+
+// NodeKind identifies what a Node represents.
+type NodeKind string
+
+const (
+	// NodeDomain is a domain or hostname, e.g. "example.com".
+	NodeDomain NodeKind = "domain"
+
+	// NodeIP is a resolved IPv4/IPv6 address.
+	NodeIP NodeKind = "ip"
+)
+
+// Node is a single vertex in the dependency graph.
+type Node struct {
+	ID   string
+	Kind NodeKind
+}
+
+// Edge is a directed relationship between two nodes, labeled with the kind
+// of DNS reference that produced it (e.g. "NS", "CNAME", "MX", "A").
+type Edge struct {
+	From  string
+	To    string
+	Label string
+}
+
+// Report is the result of Analyze: the graph itself plus the derived
+// findings callers are usually after.
+type Report struct {
+	Domain string
+	Nodes  map[string]Node
+	Edges  []Edge
+
+	// Cycles holds every cycle of domain references found while walking
+	// the graph (e.g. two domains whose NS records point at each other).
+	Cycles [][]string
+
+	// SinglePointsOfFailure lists groups of nameservers whose A records
+	// fall into the same /24 netblock, meaning a single network outage
+	// could take down every one of them. AAAA records are not looked
+	// up, so IPv6 addresses are not grouped.
+	SinglePointsOfFailure [][]string
+}
+
+// GraphViz renders r as a Graphviz "dot" document.
+func (r *Report) GraphViz() string {
+	var b strings.Builder
+	b.WriteString("digraph dnslookup {\n")
+	for _, n := range r.sortedNodes() {
+		shape := "box"
+		if n.Kind == NodeIP {
+			shape = "ellipse"
+		}
+		fmt.Fprintf(&b, "  %q [shape=%s];\n", n.ID, shape)
+	}
+	for _, e := range r.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.From, e.To, e.Label)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (r *Report) sortedNodes() []Node {
+	nodes := make([]Node, 0, len(r.Nodes))
+	for _, n := range r.Nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// builder accumulates nodes/edges while Analyze walks a domain, guarding
+// against revisiting the same domain and bounding fan-out with a worker
+// pool.
+type builder struct {
+	resolver *dnslookup.Resolver
+	workers  int
+
+	mu      sync.Mutex
+	nodes   map[string]Node
+	edges   []Edge
+	visited map[string]bool
+}
+
+// MaxWorkers is the default size of the worker pool used to resolve a
+// domain's NS/MX targets concurrently.
+const MaxWorkers = 8
+
+// Analyze walks the NS delegation, CNAME and MX chains for domain and
+// returns the resulting dependency graph, cycle report, and single points
+// of failure. Lookups are performed through r (a nil Resolver uses the
+// system default, matching dnslookup.NewDnsRecord), so repeated hops to the
+// same domain benefit from whatever caching r has configured.
+func Analyze(ctx context.Context, domain string, r *dnslookup.Resolver) (*Report, error) {
+	b := &builder{
+		resolver: r,
+		workers:  MaxWorkers,
+		nodes:    make(map[string]Node),
+		visited:  make(map[string]bool),
+	}
+
+	b.addNode(domain, NodeDomain)
+	if err := b.walk(ctx, domain); err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Domain: domain,
+		Nodes:  b.nodes,
+		Edges:  b.edges,
+	}
+	report.Cycles = findCycles(report.Nodes, report.Edges)
+	report.SinglePointsOfFailure = findSharedNetblocks(report.Nodes, report.Edges)
+
+	return report, nil
+}
+
+// walk resolves domain's NS, CNAME and MX records, recording each as an
+// edge, and recurses into every target it finds using a bounded worker
+// pool so a domain with many delegations doesn't cause unbounded fan-out.
+func (b *builder) walk(ctx context.Context, domain string) error {
+	if b.markVisited(domain) {
+		return nil
+	}
+
+	rec := dnslookup.NewDnsRecordWithResolver(domain, b.resolver)
+
+	var next []string
+
+	if nsRecords, err := rec.GetNsRecordsCtx(ctx); err == nil {
+		for _, ns := range nsRecords {
+			target := strings.TrimSuffix(ns.Host, ".")
+			b.addEdge(domain, target, "NS")
+			next = append(next, target)
+		}
+	}
+
+	if cname, err := rec.GetCnameRecordsCtx(ctx); err == nil && cname != "" {
+		target := strings.TrimSuffix(cname, ".")
+		if target != domain {
+			b.addEdge(domain, target, "CNAME")
+			next = append(next, target)
+		}
+	}
+
+	if mxRecords, err := rec.GetMxRecordsCtx(ctx); err == nil {
+		for _, mx := range mxRecords {
+			target := strings.TrimSuffix(mx.Host, ".")
+			b.addEdge(domain, target, "MX")
+			next = append(next, target)
+		}
+	}
+
+	if ips, err := rec.GetARecordsCtx(ctx); err == nil {
+		for _, ip := range ips {
+			b.addIPEdge(domain, ip, "A")
+		}
+	}
+
+	return b.fanOut(ctx, next)
+}
+
+// fanOut walks every domain in targets concurrently, bounded by b.workers.
+func (b *builder) fanOut(ctx context.Context, targets []string) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	sem := make(chan struct{}, b.workers)
+	var wg sync.WaitGroup
+	var firstErr error
+	var errOnce sync.Once
+
+	for _, target := range targets {
+		target := target
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := b.walk(ctx, target); err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}()
+	}
+
+	wg.Wait()
+	return firstErr
+}
+
+func (b *builder) markVisited(domain string) (alreadyVisited bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.visited[domain] {
+		return true
+	}
+	b.visited[domain] = true
+	return false
+}
+
+func (b *builder) addNode(id string, kind NodeKind) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nodes[id] = Node{ID: id, Kind: kind}
+}
+
+func (b *builder) addEdge(from, to, label string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nodes[from] = Node{ID: from, Kind: NodeDomain}
+	b.nodes[to] = Node{ID: to, Kind: NodeDomain}
+	b.edges = append(b.edges, Edge{From: from, To: to, Label: label})
+}
+
+func (b *builder) addIPEdge(from string, ip net.IP, label string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	to := ip.String()
+	b.nodes[from] = Node{ID: from, Kind: NodeDomain}
+	b.nodes[to] = Node{ID: to, Kind: NodeIP}
+	b.edges = append(b.edges, Edge{From: from, To: to, Label: label})
+}
+
+// findCycles runs a depth-first search from each unvisited domain node and
+// records one cycle per edge back to a node on the current search path,
+// expressed as the ordered list of domains in the cycle. A cycle that is
+// only reached through a node the search has already finished with is not
+// reported.
+func findCycles(nodes map[string]Node, edges []Edge) [][]string {
+	adjacency := make(map[string][]string)
+	for _, e := range edges {
+		if nodes[e.To].Kind != NodeDomain {
+			continue
+		}
+		adjacency[e.From] = append(adjacency[e.From], e.To)
+	}
+
+	var cycles [][]string
+	visited := make(map[string]bool)
+
+	var stack []string
+	onStack := make(map[string]bool)
+
+	var dfs func(node string)
+	dfs = func(node string) {
+		visited[node] = true
+		onStack[node] = true
+		stack = append(stack, node)
+
+		for _, next := range adjacency[node] {
+			if onStack[next] {
+				if idx := indexOf(stack, next); idx >= 0 {
+					cycle := append([]string{}, stack[idx:]...)
+					cycles = append(cycles, cycle)
+				}
+				continue
+			}
+			if !visited[next] {
+				dfs(next)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		onStack[node] = false
+	}
+
+	for node := range adjacency {
+		if !visited[node] {
+			dfs(node)
+		}
+	}
+
+	return cycles
+}
+
+func indexOf(s []string, v string) int {
+	for i, item := range s {
+		if item == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// findSharedNetblocks groups NS-referenced domains whose A records share a
+// netblockPrefix (/24 for IPv4, /48 for IPv6), flagging them as a single
+// point of failure: a network-level outage there would take down every
+// nameserver in the group at once. Only "A" edges are considered, and walk
+// records those from IPv4 lookups only, so every group is a /24.
+func findSharedNetblocks(nodes map[string]Node, edges []Edge) [][]string {
+	nsTargets := make(map[string]bool)
+	for _, e := range edges {
+		if e.Label == "NS" {
+			nsTargets[e.To] = true
+		}
+	}
+
+	prefixToDomains := make(map[string]map[string]bool)
+	for _, e := range edges {
+		if e.Label != "A" || !nsTargets[e.From] {
+			continue
+		}
+		ip := net.ParseIP(e.To)
+		if ip == nil {
+			continue
+		}
+		prefix := netblockPrefix(ip)
+		if prefixToDomains[prefix] == nil {
+			prefixToDomains[prefix] = make(map[string]bool)
+		}
+		prefixToDomains[prefix][e.From] = true
+	}
+
+	var groups [][]string
+	for _, domains := range prefixToDomains {
+		if len(domains) < 2 {
+			continue
+		}
+		group := make([]string, 0, len(domains))
+		for d := range domains {
+			group = append(group, d)
+		}
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+
+	return groups
+}
+
+func netblockPrefix(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.0/24", v4[0], v4[1], v4[2])
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
@@ -7,10 +7,6 @@ import (
 	"sync"
 )
 
-// mutex is a sync.Mutex object created to be used to prevent
-// problems that may arise due to concurrency during DNS lookup.
-var mutex sync.Mutex
-
 // DnsRecord represents a DNS record for a specific domain
 type DnsRecord struct {
 	// domain , the domain for which this DNS record is stored
@@ -33,6 +29,23 @@ type DnsRecord struct {
 
 	// txtRecords, a slice of TXT (Text) records.
 	txtRecords []string
+
+	// aaaaRecords, a slice of AAAA (IPv6) records.
+	aaaaRecords []net.IP
+
+	// resolver, the backend used for lookups on this instance. A nil
+	// resolver means the system default resolver is used, preserving
+	// the behavior of NewDnsRecord.
+	resolver *Resolver
+
+	// mu guards every cached field above. Each DnsRecord gets its own
+	// mutex rather than sharing one package-level lock, so lookups for
+	// different domains never block each other.
+	mu sync.RWMutex
+
+	// sf coalesces concurrent callers asking for the same rrtype on
+	// this instance into a single in-flight net.LookupX call.
+	sf singleFlightGroup
 }
 
 // NewDnsRecord is a constructor function simplifies the process of
@@ -51,8 +64,9 @@ func NewDnsRecord(domainName string) *DnsRecord {
 // GetARecords method is designed to retrieve the A
 // records for the given domain. It caches the results in
 // the 'DnsRecord' instance to avoid unnecessary DNS lookups
-// and uses a mutex to ensure safe concurrent access to the
-// cached data.
+// and uses a per-instance lock to ensure safe concurrent access to the
+// cached data. Concurrent callers for the same instance coalesce onto a
+// single in-flight lookup.
 //
 // If the A records are already cached, it returns the cached
 // records; otherwise, it performs a DNS lookup to fetch the
@@ -60,22 +74,34 @@ func NewDnsRecord(domainName string) *DnsRecord {
 //
 // It returns a slice of that domain's IP addresses in the DnsRecords instance
 func (d *DnsRecord) GetARecords() []net.IP {
-	if d.aRecords == nil {
-		aRecords, err := net.LookupIP(d.domain)
-		if err == nil {
-			mutex.Lock()
-			d.aRecords = append(d.aRecords, aRecords...)
-			mutex.Unlock()
-		}
+	d.mu.RLock()
+	if d.aRecords != nil {
+		defer d.mu.RUnlock()
 		return d.aRecords
 	}
-	return d.aRecords
+	d.mu.RUnlock()
+
+	value, _ := d.sf.do("A", func() (interface{}, error) {
+		return net.LookupIP(d.domain)
+	})
+	aRecords, _ := value.([]net.IP)
+
+	d.mu.Lock()
+	if d.aRecords == nil {
+		d.aRecords = append(d.aRecords, aRecords...)
+	}
+	result := d.aRecords
+	d.mu.Unlock()
+
+	return result
 }
 
 // GetCnameRecords method is designed to retrieve the CNAME
 // record for the given domain. It caches the result in the
 // DnsRecord instance to avoid unnecesarry DNS lookups and uses
-// a mutex to ensure safe concurrent access to the cached data.
+// a per-instance lock to ensure safe concurrent access to the cached data.
+// Concurrent callers for the same instance coalesce onto a single
+// in-flight lookup.
 //
 // If the CNAME record is already cached, it returns the cached
 // record; otherwise, it performs a DNS lookup to fetch the records
@@ -84,22 +110,33 @@ func (d *DnsRecord) GetARecords() []net.IP {
 // It returns a string of Canonical Name Record belongs to that domain
 // in the DnsRecord instance
 func (d *DnsRecord) GetCnameRecords() string {
-	if d.cnameRecords == "" {
-		cnameRecords, err := net.LookupCNAME(d.domain)
-		if err == nil {
-			mutex.Lock()
-			d.cnameRecords = cnameRecords
-		}
-		mutex.Unlock()
+	d.mu.RLock()
+	if d.cnameRecords != "" {
+		defer d.mu.RUnlock()
 		return d.cnameRecords
 	}
-	return d.cnameRecords
+	d.mu.RUnlock()
+
+	value, _ := d.sf.do("CNAME", func() (interface{}, error) {
+		return net.LookupCNAME(d.domain)
+	})
+	cname, _ := value.(string)
+
+	d.mu.Lock()
+	if d.cnameRecords == "" {
+		d.cnameRecords = cname
+	}
+	result := d.cnameRecords
+	d.mu.Unlock()
+
+	return result
 }
 
 // GetMxRecords method is designed to retrieve MX records for the
 // given domain. It caches the result in the DnsRecords instance to
-// avoid unnecessary DNS lookups and uses a mutex to ensure safe concurrent
-// access to the cached data.
+// avoid unnecessary DNS lookups and uses a per-instance lock to ensure
+// safe concurrent access to the cached data. Concurrent callers for the
+// same instance coalesce onto a single in-flight lookup.
 //
 // If the MX record is already cached, it returns the cached
 // record; otherwise, it performs a DNS lookup to fetch the records
@@ -108,25 +145,36 @@ func (d *DnsRecord) GetCnameRecords() string {
 // It returns a slice of mail server names belongs to that domain in the
 // DnsRecord instance
 func (d *DnsRecord) GetMxRecords() []net.MX {
+	d.mu.RLock()
+	if d.mxRecords != nil {
+		defer d.mu.RUnlock()
+		return d.mxRecords
+	}
+	d.mu.RUnlock()
+
+	value, _ := d.sf.do("MX", func() (interface{}, error) {
+		return net.LookupMX(d.domain)
+	})
+	mxRecords, _ := value.([]*net.MX)
+
+	d.mu.Lock()
 	if d.mxRecords == nil {
-		mxRecords, err := net.LookupMX(d.domain)
-		if err == nil {
-			mutex.Lock()
-			d.mxRecords = make([]net.MX, len(mxRecords))
-			for i, record := range mxRecords {
-				d.mxRecords[i] = *record
-			}
-			mutex.Unlock()
+		d.mxRecords = make([]net.MX, len(mxRecords))
+		for i, record := range mxRecords {
+			d.mxRecords[i] = *record
 		}
-		return d.mxRecords
 	}
-	return d.mxRecords
+	result := d.mxRecords
+	d.mu.Unlock()
+
+	return result
 }
 
 // GetNsRecords method is designed to retrieve NS records for the
 // given domain. It caches the result in the DnsRecord instance to avoid
-// unnecessary DNS lookups and uses a mutex to ensure safe concurrent access
-// to the cached data.
+// unnecessary DNS lookups and uses a per-instance lock to ensure safe
+// concurrent access to the cached data. Concurrent callers for the same
+// instance coalesce onto a single in-flight lookup.
 //
 // If NS records are already cached, it returns the cached record;
 // otherwise, it performs a DNS lookup to fetch the records and caches
@@ -135,24 +183,36 @@ func (d *DnsRecord) GetMxRecords() []net.MX {
 // It returns a slice of Name Server addresses belong to that domain
 // in the DnsRecord instance
 func (d *DnsRecord) GetNsRecords() []net.NS {
+	d.mu.RLock()
+	if d.nsRecords != nil {
+		defer d.mu.RUnlock()
+		return d.nsRecords
+	}
+	d.mu.RUnlock()
+
+	value, _ := d.sf.do("NS", func() (interface{}, error) {
+		return net.LookupNS(d.domain)
+	})
+	nsRecords, _ := value.([]*net.NS)
+
+	d.mu.Lock()
 	if d.nsRecords == nil {
-		nsRecords, err := net.LookupNS(d.domain)
-		if err == nil {
-			mutex.Lock()
-			d.nsRecords = make([]net.NS, len(nsRecords))
-			for i, record := range nsRecords {
-				d.nsRecords[i] = *record
-			}
-			mutex.Unlock()
+		d.nsRecords = make([]net.NS, len(nsRecords))
+		for i, record := range nsRecords {
+			d.nsRecords[i] = *record
 		}
 	}
-	return d.nsRecords
+	result := d.nsRecords
+	d.mu.Unlock()
+
+	return result
 }
 
 // GetPtrRecords method is designed to retrieve PTR records for the
 // given domain. It caches the result in the DnsRecord instance to avoid
-// unnecessary DNS lookups and uses a mutex to ensure safe concurrent access
-// to the cached data.
+// unnecessary DNS lookups and uses a per-instance lock to ensure safe
+// concurrent access to the cached data. Concurrent callers for the same
+// instance coalesce onto a single in-flight lookup.
 //
 // If PTR records are already cached , it returns the cached records;
 // otherwise, it performs a DNS lookup to fetch the records and caches them
@@ -161,25 +221,40 @@ func (d *DnsRecord) GetNsRecords() []net.NS {
 // It returns a slice of strings of Pointer records belong to that domain
 // in the DnsRecord instance
 func (d *DnsRecord) GetPtrRecords() []string {
-	if d.ptrRecords == nil {
-		if d.aRecords == nil {
-			d.GetARecords()
-			for _, v := range d.aRecords {
-				ptr, _ := net.LookupAddr(v.String())
-				d.ptrRecords = append(d.ptrRecords, ptr...)
-			}
-		}
-		for _, v := range d.aRecords {
+	d.mu.RLock()
+	if d.ptrRecords != nil {
+		defer d.mu.RUnlock()
+		return d.ptrRecords
+	}
+	d.mu.RUnlock()
+
+	aRecords := d.GetARecords()
+
+	value, _ := d.sf.do("PTR", func() (interface{}, error) {
+		var ptrRecords []string
+		for _, v := range aRecords {
 			ptr, _ := net.LookupAddr(v.String())
-			d.ptrRecords = append(d.ptrRecords, ptr...)
+			ptrRecords = append(ptrRecords, ptr...)
 		}
+		return ptrRecords, nil
+	})
+	ptrRecords, _ := value.([]string)
+
+	d.mu.Lock()
+	if d.ptrRecords == nil {
+		d.ptrRecords = ptrRecords
 	}
-	return d.ptrRecords
+	result := d.ptrRecords
+	d.mu.Unlock()
+
+	return result
 }
 
 // GetTxtRecords method is designed to retrieve TXT records for the given
 // domain. It caches the result in the DnsRecords instance to avoid unnecessary
-// DNS lookups and uses a mutex to ensure safe concurrent access to the cached data
+// DNS lookups and uses a per-instance lock to ensure safe concurrent access
+// to the cached data. Concurrent callers for the same instance coalesce onto
+// a single in-flight lookup.
 //
 // If TXT records are already cached, it returns the cached records; otherwise;
 // it performs a DNS lookup to fetch the records and caches them for future use
@@ -187,15 +262,26 @@ func (d *DnsRecord) GetPtrRecords() []string {
 // It returns a slice of strings of TXT records belong to that domain
 // in DnsRecord instance
 func (d *DnsRecord) GetTxtRecords() []string {
+	d.mu.RLock()
+	if d.txtRecords != nil {
+		defer d.mu.RUnlock()
+		return d.txtRecords
+	}
+	d.mu.RUnlock()
+
+	value, _ := d.sf.do("TXT", func() (interface{}, error) {
+		return net.LookupTXT(d.domain)
+	})
+	txtRecords, _ := value.([]string)
+
+	d.mu.Lock()
 	if d.txtRecords == nil {
-		txtRecords, err := net.LookupTXT(d.domain)
-		if err == nil {
-			mutex.Lock()
-			d.txtRecords = txtRecords
-		}
-		mutex.Unlock()
+		d.txtRecords = txtRecords
 	}
-	return d.txtRecords
+	result := d.txtRecords
+	d.mu.Unlock()
+
+	return result
 }
 
 // GetAllRecords method retrieves and collect various DNS records
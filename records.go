@@ -0,0 +1,254 @@
+package dnslookup
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// TrustStatus describes the DNSSEC validation outcome of an RRSet.
+type TrustStatus int
+
+const (
+	// Indeterminate means the RRSet was never validated, either because
+	// Resolver.Validate was false or because validation could not be
+	// attempted (see RRSet.ValidationErr).
+	Indeterminate TrustStatus = iota
+
+	// Secure means a DNSKEY published by the RRSet's own zone signed it
+	// with a verifying RRSIG. This does not walk the delegation chain up
+	// to a root trust anchor via parent DS records, so it certifies
+	// internal consistency ("this zone's keys sign this data"), not a
+	// globally anchored chain of trust - see Resolver.validateRRSet.
+	Secure
+
+	// Insecure means the zone published no DNSKEY, or the answer carried
+	// no RRSIG: the zone isn't signed.
+	Insecure
+
+	// Bogus means the zone published a DNSKEY but no RRSIG verified
+	// against it - a broken or tampered-with signature.
+	Bogus
+)
+
+// ErrUnsupported is returned for RR types GetAnyRecord has no decoder for.
+var ErrUnsupported = errors.New("dnslookup: not supported by the standard library resolver")
+
+// RRSet groups the records returned for a single (domain, rrtype) lookup
+// together with their DNSSEC trust status, so callers can distinguish "no
+// record" from "signature failure" instead of only getting a bare error.
+type RRSet struct {
+	// Domain is the queried domain.
+	Domain string
+
+	// Type is the queried RR type, e.g. "AAAA", "SRV", "CAA".
+	Type string
+
+	// Records holds the RRs themselves; its concrete element type
+	// depends on Type (e.g. []net.IP for "AAAA").
+	Records interface{}
+
+	// Status is the RRSet's DNSSEC trust status. It is Indeterminate
+	// unless the originating Resolver has Validate set to true.
+	Status TrustStatus
+
+	// ValidationErr explains why Status is Bogus or Indeterminate, if
+	// applicable.
+	ValidationErr error
+
+	// Err is the error from looking up Records itself (e.g. NXDOMAIN),
+	// as opposed to ValidationErr, which explains Status. Checking both
+	// separately is what lets a caller tell "no record" apart from
+	// "signature failure" instead of only getting a bare error.
+	Err error
+}
+
+// CAARecord represents a single Certification Authority Authorization
+// record: a flag, a tag ("issue", "issuewild", "iodef") and its value.
+type CAARecord struct {
+	Flag  uint8
+	Tag   string
+	Value string
+}
+
+// SOARecord represents a zone's Start of Authority record.
+type SOARecord struct {
+	PrimaryNs   string
+	RespMailbox string
+	Serial      uint32
+	Refresh     uint32
+	Retry       uint32
+	Expire      uint32
+	MinTTL      uint32
+}
+
+// GetAAAARecords retrieves the AAAA (IPv6) records for d's domain, caching
+// the result on d like the other GetX methods.
+func (d *DnsRecord) GetAAAARecords(ctx context.Context) ([]net.IP, error) {
+	d.mu.RLock()
+	cached := d.aaaaRecords
+	d.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "AAAA", func() (interface{}, error) {
+		return d.netResolver().LookupIP(ctx, "ip6", d.domain)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ips, _ := value.([]net.IP)
+
+	d.mu.Lock()
+	if d.aaaaRecords == nil {
+		d.aaaaRecords = append(d.aaaaRecords, ips...)
+	}
+	result := d.aaaaRecords
+	d.mu.Unlock()
+
+	return result, nil
+}
+
+// GetSRVRecords retrieves the SRV records published under the given
+// service and proto (e.g. "sip", "tcp") for d's domain. Per RFC 2782 the
+// actual query name is _service._proto.domain, which net.LookupSRV builds
+// for us.
+func (d *DnsRecord) GetSRVRecords(ctx context.Context, service, proto string) ([]*net.SRV, error) {
+	key := "SRV:" + service + ":" + proto
+	value, err := d.resolver.cachedLookup(ctx, d.domain, key, func() (interface{}, error) {
+		_, srvs, err := d.netResolver().LookupSRV(ctx, service, proto, d.domain)
+		return srvs, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	srvs, _ := value.([]*net.SRV)
+	return srvs, nil
+}
+
+// GetCAARecords retrieves the CAA records for d's domain. The standard
+// library resolver has no notion of CAA records, so this sends a raw wire
+// query itself via d's Resolver instead of going through net.Resolver,
+// which means d's Resolver must have Servers set explicitly - see
+// ErrNoWireServers.
+func (d *DnsRecord) GetCAARecords(ctx context.Context) ([]CAARecord, error) {
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "CAA", func() (interface{}, error) {
+		rrs, err := d.resolver.queryWire(ctx, d.domain, typeCAA, false)
+		if err != nil {
+			return nil, err
+		}
+
+		var caas []CAARecord
+		for _, rr := range rrs {
+			if caa, ok := rr.RData.(CAARecord); ok {
+				caas = append(caas, caa)
+			}
+		}
+		return caas, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	caas, _ := value.([]CAARecord)
+	return caas, nil
+}
+
+// GetSOARecord retrieves the SOA record for d's domain, the same way
+// GetCAARecords does.
+func (d *DnsRecord) GetSOARecord(ctx context.Context) (*SOARecord, error) {
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "SOA", func() (interface{}, error) {
+		rrs, err := d.resolver.queryWire(ctx, d.domain, typeSOA, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rr := range rrs {
+			if soa, ok := rr.RData.(SOARecord); ok {
+				return &soa, nil
+			}
+		}
+		return nil, errors.New("dnslookup: no SOA record in the answer")
+	})
+	if err != nil {
+		return nil, err
+	}
+	soa, _ := value.(*SOARecord)
+	return soa, nil
+}
+
+// GetAnyRecord retrieves the RRSet for an arbitrary RR type, identified by
+// its numeric value as defined in RFC 1035 and RFC 8659 (e.g. 28 for AAAA,
+// 257 for CAA).
+//
+// Only the types this package already knows how to parse are supported;
+// anything else returns ErrUnsupported. When d's Resolver has Validate set,
+// the RRSet's Status is also populated by a dedicated DNSSEC query - see
+// Resolver.validateRRSet for exactly what that does and does not prove.
+func (d *DnsRecord) GetAnyRecord(ctx context.Context, rrtype uint16) (*RRSet, error) {
+	rrset := &RRSet{Domain: d.domain, Status: Indeterminate}
+
+	var err error
+	switch rrtype {
+	case typeA:
+		rrset.Type = "A"
+		rrset.Records, err = d.GetARecordsCtx(ctx)
+	case typeAAAA:
+		rrset.Type = "AAAA"
+		rrset.Records, err = d.GetAAAARecords(ctx)
+	case typeCNAME:
+		rrset.Type = "CNAME"
+		rrset.Records, err = d.GetCnameRecordsCtx(ctx)
+	case typeMX:
+		rrset.Type = "MX"
+		rrset.Records, err = d.GetMxRecordsCtx(ctx)
+	case typeNS:
+		rrset.Type = "NS"
+		rrset.Records, err = d.GetNsRecordsCtx(ctx)
+	case typeTXT:
+		rrset.Type = "TXT"
+		rrset.Records, err = d.GetTxtRecordsCtx(ctx)
+	case typeSOA:
+		rrset.Type = "SOA"
+		rrset.Records, err = d.GetSOARecord(ctx)
+	case typeCAA:
+		rrset.Type = "CAA"
+		rrset.Records, err = d.GetCAARecords(ctx)
+	default:
+		return nil, ErrUnsupported
+	}
+
+	if d.resolver != nil && d.resolver.Validate {
+		rrset.Status, rrset.ValidationErr = d.resolver.validateRRSet(ctx, d.domain, rrtype)
+	}
+
+	rrset.Err = err
+	return rrset, err
+}
+
+// GetAllRecordsCtx retrieves every RR type GetAnyRecord supports (A, AAAA,
+// CNAME, MX, NS, TXT, SOA, CAA) plus PTR for d's domain, honoring ctx
+// cancellation/deadlines and routing lookups through d's configured
+// Resolver like the other *Ctx methods.
+//
+// Unlike the legacy GetAllRecords, which silently drops every lookup
+// error, each entry here is an *RRSet: RRSet.Err reports a failed lookup
+// (e.g. NXDOMAIN), and - when d's Resolver has Validate set - RRSet.Status
+// and RRSet.ValidationErr report whether the RRset's DNSSEC signature
+// checked out. That lets a caller tell "no record" apart from "signature
+// failure" instead of only getting a bare error.
+func (d *DnsRecord) GetAllRecordsCtx(ctx context.Context) map[string]*RRSet {
+	rrtypes := []uint16{typeA, typeAAAA, typeCNAME, typeMX, typeNS, typeTXT, typeSOA, typeCAA}
+
+	results := make(map[string]*RRSet, len(rrtypes)+1)
+	for _, rrtype := range rrtypes {
+		rrset, err := d.GetAnyRecord(ctx, rrtype)
+		rrset.Err = err
+		results[rrset.Type] = rrset
+	}
+
+	ptr, err := d.GetPtrRecordsCtx(ctx)
+	results["PTR"] = &RRSet{Domain: d.domain, Type: "PTR", Status: Indeterminate, Records: ptr, Err: err}
+
+	return results
+}
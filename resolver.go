@@ -0,0 +1,545 @@
+package dnslookup
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Transport identifies the network transport a Resolver should use when
+// talking to its upstream nameservers.
+type Transport int
+
+const (
+	// TransportUDP sends queries over plain UDP. This is the default.
+	TransportUDP Transport = iota
+
+	// TransportTCP sends queries over plain TCP.
+	TransportTCP
+
+	// TransportDoT sends queries over DNS-over-TLS (RFC 7858): the same
+	// length-prefixed framing as TransportTCP, wrapped in a TLS
+	// handshake.
+	TransportDoT
+
+	// TransportDoQ sends queries over DNS-over-QUIC (RFC 9250). This is
+	// not implemented: it needs a QUIC stack, and this package is
+	// standard-library only with no vendored dependencies. Lookups that
+	// request it fail with an explicit "not implemented" error rather
+	// than silently falling back to an unencrypted transport.
+	TransportDoQ
+)
+
+// String implements fmt.Stringer.
+func (t Transport) String() string {
+	switch t {
+	case TransportUDP:
+		return "udp"
+	case TransportTCP:
+		return "tcp"
+	case TransportDoT:
+		return "dot"
+	case TransportDoQ:
+		return "doq"
+	default:
+		return "unknown"
+	}
+}
+
+// Resolver configures how DnsRecord performs its lookups: which upstream
+// nameservers to use, which transport to speak to them over, and whether to
+// force Go's pure-Go resolver instead of the system/cgo one.
+//
+// A zero-value Resolver is valid and behaves like the package-level
+// net.LookupX functions used by NewDnsRecord.
+type Resolver struct {
+	// Servers is the list of upstream nameservers to query, e.g.
+	// []string{"1.1.1.1:53"}. If empty, the net.LookupX-based methods use
+	// the system's configured nameservers instead, but the raw wire
+	// queries behind GetCAARecords, GetSOARecord, DNSSEC validation, and
+	// cachedLookup's TTL-aware fast path have no portable way to read the
+	// system resolver's configuration and require Servers to be set
+	// explicitly - see ErrNoWireServers.
+	Servers []string
+
+	// Transport selects the network transport used to reach Servers.
+	// TransportDoQ is not implemented and causes lookups to fail with an
+	// error - see the TransportDoQ constant.
+	Transport Transport
+
+	// PreferGo forces use of Go's built-in DNS resolver, even on systems
+	// where cgo is available. It mirrors net.Resolver.PreferGo.
+	PreferGo bool
+
+	// Dial, if set, is used to establish the connection to the upstream
+	// nameserver instead of the default dialer. This allows tests to
+	// inject a fake server, or callers to tunnel DNS over an arbitrary
+	// transport.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// Cache, if set, is consulted before performing a lookup and
+	// populated with the result afterwards. A nil Cache disables
+	// caching beyond the per-instance storage DnsRecord already does.
+	Cache Cache
+
+	// Validate requests DNSSEC validation of the RRSets GetAnyRecord (and
+	// so GetAllRecordsCtx) returns: their Status and ValidationErr are
+	// filled in by a separate raw wire query with the DO bit set, which
+	// requires Servers to be set - see Resolver.validateRRSet for what a
+	// Secure status does and does not prove.
+	Validate bool
+
+	sf singleFlightGroup
+}
+
+// netResolver builds the stdlib *net.Resolver that backs r's lookups.
+func (r *Resolver) netResolver() *net.Resolver {
+	if r == nil {
+		return net.DefaultResolver
+	}
+
+	if len(r.Servers) == 0 && r.Dial == nil && !r.PreferGo && r.Transport == TransportUDP {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, address string) (net.Conn, error) {
+			if server := r.pickServer(); server != "" {
+				address = server
+			}
+			return r.dialTransport(ctx, address)
+		},
+	}
+}
+
+// pickServer returns the first configured upstream server, or an empty
+// string if none are configured, in which case netResolver's Dial uses the
+// nameserver address Go's resolver picked from the system configuration.
+func (r *Resolver) pickServer() string {
+	if len(r.Servers) == 0 {
+		return ""
+	}
+	return r.Servers[0]
+}
+
+// sfResult is what cachedLookup's singleflight call actually produces: the
+// value a GetX method expects plus the real TTL it should be cached under,
+// when one is known.
+type sfResult struct {
+	value interface{}
+	ttl   time.Duration
+}
+
+// rrtypeToQtype maps the rrtype strings cachedLookup is called with to the
+// wire QTYPE queryWire needs, for the types wireValueFor knows how to decode
+// back into the shape fn would have returned.
+//
+// PTR is deliberately absent: GetPtrRecordsCtx's fn queries the
+// in-addr.arpa/ip6.arpa name derived from each A record's IP, not domain
+// itself, so there is no single (domain, typePTR) wire query that matches
+// what fn does - cachedLookup always falls back to fn for it.
+func rrtypeToQtype(rrtype string) (uint16, bool) {
+	switch rrtype {
+	case "A":
+		return typeA, true
+	case "AAAA":
+		return typeAAAA, true
+	case "CNAME":
+		return typeCNAME, true
+	case "MX":
+		return typeMX, true
+	case "NS":
+		return typeNS, true
+	case "TXT":
+		return typeTXT, true
+	default:
+		return 0, false
+	}
+}
+
+// cachedLookup runs fn for (domain, rrtype), consulting r.Cache first and
+// populating it afterwards. Concurrent callers for the same key coalesce
+// onto a single call to fn via r's singleflight group.
+//
+// When r.Cache is configured and rrtype is one wireValueFor understands,
+// cachedLookup first tries queryWire directly so the cache entry's TTL
+// reflects the record's real TTL as read off the wire, rather than always
+// falling back to DefaultTTL. fn (the net.LookupX-based path) is used
+// whenever the wire query fails for a reason other than NXDOMAIN - e.g. no
+// route to the configured nameservers - so lookups keep working even where
+// raw DNS queries can't reach out.
+//
+// A nil Resolver just runs fn directly.
+func (r *Resolver) cachedLookup(ctx context.Context, domain, rrtype string, fn func() (interface{}, error)) (interface{}, error) {
+	if r == nil {
+		return fn()
+	}
+
+	key := domain + "/" + rrtype
+
+	if r.Cache != nil {
+		if value, err, ok := r.Cache.Get(key); ok {
+			return value, err
+		}
+	}
+
+	raw, err := r.sf.do(key, func() (interface{}, error) {
+		if r.Cache != nil {
+			if qtype, ok := rrtypeToQtype(rrtype); ok {
+				rrs, wireErr := r.queryWire(ctx, domain, qtype, false)
+				switch {
+				case wireErr == nil:
+					if value, ttl, ok := wireValueFor(rrtype, rrs); ok {
+						return sfResult{value: value, ttl: ttl}, nil
+					}
+				case errors.Is(wireErr, ErrNXDomain):
+					return sfResult{}, wireErr
+				}
+			}
+		}
+
+		value, err := fn()
+		return sfResult{value: value}, err
+	})
+
+	result, _ := raw.(sfResult)
+	if r.Cache != nil {
+		r.Cache.Set(key, result.value, err, result.ttl)
+	}
+	return result.value, err
+}
+
+// wireValueFor converts the answer RRs of a successful wire query for rrtype
+// into the same shape the corresponding GetX method's fn would have
+// returned, plus the TTL to cache it under (the minimum TTL across the
+// returned RRs, per RFC 2181 section 5.2). ok is false if rrs is empty or
+// none of its records carry a payload wireValueFor knows how to convert.
+func wireValueFor(rrtype string, rrs []wireRR) (value interface{}, ttl time.Duration, ok bool) {
+	if len(rrs) == 0 {
+		return nil, 0, false
+	}
+
+	minTTL := rrs[0].TTL
+	for _, rr := range rrs[1:] {
+		if rr.TTL < minTTL {
+			minTTL = rr.TTL
+		}
+	}
+	ttl = time.Duration(minTTL) * time.Second
+
+	switch rrtype {
+	case "A", "AAAA":
+		var ips []net.IP
+		for _, rr := range rrs {
+			if ip, ok := rr.RData.(net.IP); ok {
+				ips = append(ips, ip)
+			}
+		}
+		return ips, ttl, len(ips) > 0
+
+	case "CNAME":
+		name, ok := rrs[0].RData.(string)
+		if !ok {
+			return nil, 0, false
+		}
+		return name + ".", ttl, true
+
+	case "MX":
+		var mx []*net.MX
+		for _, rr := range rrs {
+			if m, ok := rr.RData.(net.MX); ok {
+				m := m
+				mx = append(mx, &m)
+			}
+		}
+		return mx, ttl, len(mx) > 0
+
+	case "NS":
+		var ns []*net.NS
+		for _, rr := range rrs {
+			if name, ok := rr.RData.(string); ok {
+				ns = append(ns, &net.NS{Host: name + "."})
+			}
+		}
+		return ns, ttl, len(ns) > 0
+
+	case "TXT":
+		var txt []string
+		for _, rr := range rrs {
+			if s, ok := rr.RData.(string); ok {
+				txt = append(txt, s)
+			}
+		}
+		return txt, ttl, len(txt) > 0
+
+	default:
+		return nil, 0, false
+	}
+}
+
+// TTLFor returns the remaining TTL cached for (domain, rrtype) - the same
+// cache key cachedLookup uses - if r has a Cache configured and it holds a
+// fresh entry. Callers that already ran a GetXRecordsCtx lookup (which
+// populates the cache as a side effect) can use it to recover the real TTL
+// the wire query reported, instead of assuming DefaultTTL.
+func (r *Resolver) TTLFor(domain, rrtype string) (time.Duration, bool) {
+	if r == nil || r.Cache == nil {
+		return 0, false
+	}
+	return r.Cache.TTL(domain + "/" + rrtype)
+}
+
+// RefreshExpired proactively re-runs lookups for cache entries whose TTL is
+// within threshold of expiring. It is a no-op if r has no Cache configured.
+// Only A, AAAA, CNAME, MX, NS, PTR and TXT entries are re-fetched; other
+// entries (SRV, CAA, SOA) are left as they are.
+func (r *Resolver) RefreshExpired(ctx context.Context, threshold time.Duration) {
+	if r == nil || r.Cache == nil {
+		return
+	}
+
+	r.Cache.RefreshExpired(ctx, threshold, func(ctx context.Context, key string) (interface{}, error, time.Duration) {
+		domain, rrtype := splitCacheKey(key)
+		value, err := r.refresh(ctx, domain, rrtype)
+		return value, err, 0
+	})
+}
+
+// refresh re-runs the lookup for rrtype against domain, bypassing the
+// cache, for use by RefreshExpired.
+func (r *Resolver) refresh(ctx context.Context, domain, rrtype string) (interface{}, error) {
+	uncached := &Resolver{Servers: r.Servers, Transport: r.Transport, PreferGo: r.PreferGo, Dial: r.Dial}
+	d := NewDnsRecordWithResolver(domain, uncached)
+	switch rrtype {
+	case "A":
+		return d.GetARecordsCtx(ctx)
+	case "AAAA":
+		return d.GetAAAARecords(ctx)
+	case "CNAME":
+		return d.GetCnameRecordsCtx(ctx)
+	case "MX":
+		return d.netResolver().LookupMX(ctx, domain)
+	case "NS":
+		return d.netResolver().LookupNS(ctx, domain)
+	case "PTR":
+		return d.GetPtrRecordsCtx(ctx)
+	case "TXT":
+		return d.GetTxtRecordsCtx(ctx)
+	default:
+		return nil, errors.New("dnslookup: refresh not supported for rrtype " + rrtype)
+	}
+}
+
+// splitCacheKey reverses the "domain/rrtype" encoding used by cachedLookup.
+func splitCacheKey(key string) (domain, rrtype string) {
+	for i := len(key) - 1; i >= 0; i-- {
+		if key[i] == '/' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return key, ""
+}
+
+// NewDnsRecordWithResolver is a constructor function that behaves like
+// NewDnsRecord but routes every lookup performed on the returned DnsRecord
+// through the given Resolver instead of the system default.
+//
+// domainName: the domain name the DNS record is associated with.
+// r: the Resolver to use for lookups. A nil Resolver behaves like
+// NewDnsRecord.
+//
+// NewDnsRecordWithResolver returns a *DnsRecord object.
+func NewDnsRecordWithResolver(domainName string, r *Resolver) *DnsRecord {
+	return &DnsRecord{
+		domain:   domainName,
+		resolver: r,
+	}
+}
+
+// netResolver returns the *net.Resolver that should back a lookup for d,
+// falling back to net.DefaultResolver when d was created without one.
+func (d *DnsRecord) netResolver() *net.Resolver {
+	return d.resolver.netResolver()
+}
+
+// GetARecordsCtx mirrors GetARecords but honors ctx cancellation/deadlines,
+// routes the lookup through d's configured Resolver, and - when the
+// Resolver has a Cache configured - serves from it until the entry's TTL
+// expires instead of caching the result forever.
+func (d *DnsRecord) GetARecordsCtx(ctx context.Context) ([]net.IP, error) {
+	d.mu.RLock()
+	cached := d.aRecords
+	d.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "A", func() (interface{}, error) {
+		return d.netResolver().LookupIP(ctx, "ip4", d.domain)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ips, _ := value.([]net.IP)
+
+	d.mu.Lock()
+	if d.aRecords == nil {
+		d.aRecords = append(d.aRecords, ips...)
+	}
+	result := d.aRecords
+	d.mu.Unlock()
+
+	return result, nil
+}
+
+// GetCnameRecordsCtx mirrors GetCnameRecords but honors ctx
+// cancellation/deadlines and routes the lookup through d's configured
+// Resolver.
+func (d *DnsRecord) GetCnameRecordsCtx(ctx context.Context) (string, error) {
+	d.mu.RLock()
+	cached := d.cnameRecords
+	d.mu.RUnlock()
+	if cached != "" {
+		return cached, nil
+	}
+
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "CNAME", func() (interface{}, error) {
+		return d.netResolver().LookupCNAME(ctx, d.domain)
+	})
+	if err != nil {
+		return "", err
+	}
+	cname, _ := value.(string)
+
+	d.mu.Lock()
+	d.cnameRecords = cname
+	result := d.cnameRecords
+	d.mu.Unlock()
+
+	return result, nil
+}
+
+// GetMxRecordsCtx mirrors GetMxRecords but honors ctx cancellation/deadlines
+// and routes the lookup through d's configured Resolver.
+func (d *DnsRecord) GetMxRecordsCtx(ctx context.Context) ([]net.MX, error) {
+	d.mu.RLock()
+	cached := d.mxRecords
+	d.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "MX", func() (interface{}, error) {
+		return d.netResolver().LookupMX(ctx, d.domain)
+	})
+	if err != nil {
+		return nil, err
+	}
+	mxRecords, _ := value.([]*net.MX)
+
+	d.mu.Lock()
+	d.mxRecords = make([]net.MX, len(mxRecords))
+	for i, record := range mxRecords {
+		d.mxRecords[i] = *record
+	}
+	result := d.mxRecords
+	d.mu.Unlock()
+
+	return result, nil
+}
+
+// GetNsRecordsCtx mirrors GetNsRecords but honors ctx cancellation/deadlines
+// and routes the lookup through d's configured Resolver.
+func (d *DnsRecord) GetNsRecordsCtx(ctx context.Context) ([]net.NS, error) {
+	d.mu.RLock()
+	cached := d.nsRecords
+	d.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "NS", func() (interface{}, error) {
+		return d.netResolver().LookupNS(ctx, d.domain)
+	})
+	if err != nil {
+		return nil, err
+	}
+	nsRecords, _ := value.([]*net.NS)
+
+	d.mu.Lock()
+	d.nsRecords = make([]net.NS, len(nsRecords))
+	for i, record := range nsRecords {
+		d.nsRecords[i] = *record
+	}
+	result := d.nsRecords
+	d.mu.Unlock()
+
+	return result, nil
+}
+
+// GetTxtRecordsCtx mirrors GetTxtRecords but honors ctx cancellation/deadlines
+// and routes the lookup through d's configured Resolver.
+func (d *DnsRecord) GetTxtRecordsCtx(ctx context.Context) ([]string, error) {
+	d.mu.RLock()
+	cached := d.txtRecords
+	d.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "TXT", func() (interface{}, error) {
+		return d.netResolver().LookupTXT(ctx, d.domain)
+	})
+	if err != nil {
+		return nil, err
+	}
+	txtRecords, _ := value.([]string)
+
+	d.mu.Lock()
+	d.txtRecords = txtRecords
+	result := d.txtRecords
+	d.mu.Unlock()
+
+	return result, nil
+}
+
+// GetPtrRecordsCtx mirrors GetPtrRecords but honors ctx cancellation/deadlines
+// and routes the lookup through d's configured Resolver.
+func (d *DnsRecord) GetPtrRecordsCtx(ctx context.Context) ([]string, error) {
+	d.mu.RLock()
+	cached := d.ptrRecords
+	d.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	aRecords, err := d.GetARecordsCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := d.resolver.cachedLookup(ctx, d.domain, "PTR", func() (interface{}, error) {
+		var names []string
+		for _, ip := range aRecords {
+			n, err := d.netResolver().LookupAddr(ctx, ip.String())
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, n...)
+		}
+		return names, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	ptrRecords, _ := value.([]string)
+
+	d.mu.Lock()
+	d.ptrRecords = ptrRecords
+	result := d.ptrRecords
+	d.mu.Unlock()
+
+	return result, nil
+}
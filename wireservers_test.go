@@ -0,0 +1,29 @@
+package dnslookup
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestNoWireServersConfigured confirms that the raw-wire-query paths
+// chunk0-3 added (CAA, SOA, DNSSEC validation) never fall back to a
+// hardcoded third-party resolver: without Resolver.Servers set explicitly,
+// they fail with ErrNoWireServers instead of silently querying a public
+// nameserver the caller never opted into.
+func TestNoWireServersConfigured(t *testing.T) {
+	d := NewDnsRecordWithResolver("example.com", &Resolver{Cache: NewLRUCache(10)})
+
+	if _, err := d.GetCAARecords(context.Background()); !errors.Is(err, ErrNoWireServers) {
+		t.Errorf("GetCAARecords: got err %v, want ErrNoWireServers", err)
+	}
+	if _, err := d.GetSOARecord(context.Background()); !errors.Is(err, ErrNoWireServers) {
+		t.Errorf("GetSOARecord: got err %v, want ErrNoWireServers", err)
+	}
+
+	r := &Resolver{Validate: true}
+	status, verr := r.validateRRSet(context.Background(), "example.com", typeA)
+	if status != Indeterminate || !errors.Is(verr, ErrNoWireServers) {
+		t.Errorf("validateRRSet: got (%v, %v), want (Indeterminate, ErrNoWireServers)", status, verr)
+	}
+}